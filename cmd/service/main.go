@@ -14,44 +14,140 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/golang-jwt/jwt/v5"
-	"github.com/redis/go-redis/v9"
+	_ "github.com/lib/pq"
 
 	"github.com/tunaaoguzhann/qr-access/core"
+	"github.com/tunaaoguzhann/qr-access/core/auth"
+	"github.com/tunaaoguzhann/qr-access/core/auth/oidc"
 )
 
 type contextKey string
 
-const userIDKey contextKey = "user_id"
+const (
+	userIDKey  contextKey = "user_id"
+	isAdminKey contextKey = "is_admin"
+)
 
 func main() {
 	cfg := loadConfig()
 
-	store := buildStore(cfg)
-	manager, err := core.NewManager(core.Config{
-		Store:  store,
-		Signer: core.NewSigner(cfg.HMACSecret),
-		MinTTL: 10 * time.Second,
-		MaxTTL: 10 * time.Minute,
+	keys := buildKeySet(cfg)
+	manager, err := core.NewManagerWithOptions(core.ManagerOptions{
+		RedisAddr:   cfg.RedisAddr,
+		StoreDriver: cfg.StoreDriver,
+		StoreDSN:    cfg.StoreDSN,
+		MinTTL:      10 * time.Second,
+		MaxTTL:      10 * time.Minute,
+		Keys:        keys,
 	})
 	if err != nil {
 		log.Fatalf("init manager: %v", err)
 	}
+	if cfg.StoreDriver != "" {
+		// Unlike Redis keys, rows in a driver-backed store (e.g. Postgres)
+		// don't expire on their own; sweep lapsed ones periodically.
+		go runLapsedSweeper(manager, 5*time.Minute)
+	}
+
+	requireAuth := authMiddleware(cfg)
+	useKeys := keys != nil
 
 	r := chi.NewRouter()
 	r.Use(rateLimit(10, time.Minute)) // basic rate limit demo
 	r.Use(loggingMiddleware)
 	r.Group(func(api chi.Router) {
-		api.With(jwtAuth(cfg.JWTSecret)).Post("/qr/generate", handleGenerate(manager, cfg.DefaultTTL))
+		api.With(requireAuth).Post("/qr/generate", handleGenerate(manager, cfg.HMACSecret, useKeys, cfg.DefaultTTL))
+		api.With(requireAuth).Post("/qr/session", handleGenerateSession(manager, cfg.HMACSecret))
+		api.With(requireAuth).Delete("/qr/tokens", handlePurge(manager))
 	})
-	r.Post("/qr/verify", handleVerify(manager))
+	r.Post("/qr/verify", handleVerify(manager, cfg.HMACSecret, useKeys))
+	r.Post("/qr/touch", handleTouch(manager, cfg.HMACSecret))
 
 	addr := ":" + strconv.Itoa(cfg.Port)
-	log.Printf("listening on %s (redis=%v)", addr, cfg.RedisAddr != "")
+	log.Printf("listening on %s (redis=%v, store_driver=%q, key_rotation=%v)", addr, cfg.RedisAddr != "", cfg.StoreDriver, useKeys)
 	if err := http.ListenAndServe(addr, r); err != nil {
 		log.Fatalf("server error: %v", err)
 	}
 }
 
+// runLapsedSweeper periodically purges lapsed tokens via Manager.Purge,
+// for Store backends (e.g. Postgres) that need an explicit sweep instead
+// of expiring entries on their own.
+func runLapsedSweeper(manager *core.Manager, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if _, err := manager.Purge(context.Background(), core.PurgeOptions{Scope: core.PurgeScopeLapsed}); err != nil {
+			log.Printf("lapsed sweep: %v", err)
+		}
+	}
+}
+
+// authMiddleware picks the request-authentication middleware: an OIDC
+// connector if one is configured (see core/auth/oidc), falling back to the
+// service's own HS256 jwtAuth otherwise. The GitHub connector (core/auth/
+// github) isn't wired here since it drives a redirect-based login flow
+// rather than authenticating a bearer token on every request; callers
+// integrating it exchange its resulting auth.Identity for a JWT via
+// /qr/generate's existing jwtAuth path instead.
+func authMiddleware(cfg config) func(http.Handler) http.Handler {
+	if cfg.OIDCIssuer != "" {
+		connector := oidc.NewConnector(oidc.IssuerConfig{
+			Issuer:   cfg.OIDCIssuer,
+			Audience: cfg.OIDCAudience,
+		})
+		return oidcAuth(connector)
+	}
+	return jwtAuth(cfg.JWTSecret)
+}
+
+// oidcAuth adapts an auth.Authenticator into the same context-populating
+// middleware shape as jwtAuth. OIDC identities never carry this service's
+// admin claim, so isAdminKey is always false for them.
+func oidcAuth(authenticator auth.Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identity, err := authenticator.Authenticate(r.Context(), r)
+			if err != nil {
+				http.Error(w, "invalid token", http.StatusUnauthorized)
+				return
+			}
+			ctx := context.WithValue(r.Context(), userIDKey, identity.UserID)
+			ctx = context.WithValue(ctx, isAdminKey, false)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// buildKeySet parses SIGNING_KEYS ("kid1:secret1,kid2:secret2", HMAC-only for
+// now) into a core.KeySet so /qr/generate and /qr/verify can go through
+// GenerateV2/VerifyV2 instead of the single-secret v1 path: rotating
+// QR_HMAC_SECRET then no longer invalidates every outstanding QR, since a
+// previously-active kid keeps verifying until it's actually removed from
+// SIGNING_KEYS. Returns nil if SIGNING_KEYS is unset, in which case the
+// service keeps using the plain secretKey-based Generate/Verify.
+//
+// The reserved "v1" kid is always registered from QR_HMAC_SECRET so VerifyV2
+// keeps accepting tokens issued before SIGNING_KEYS was adopted.
+func buildKeySet(cfg config) *core.KeySet {
+	if cfg.SigningKeys == "" {
+		return nil
+	}
+	keys := core.NewKeySet()
+	for _, pair := range strings.Split(cfg.SigningKeys, ",") {
+		kid, secret, ok := strings.Cut(pair, ":")
+		if !ok || kid == "" || secret == "" {
+			continue
+		}
+		keys.AddKey(kid, core.NewSigner(secret))
+	}
+	keys.AddKey("v1", core.NewSigner(cfg.HMACSecret))
+	if cfg.ActiveKID != "" {
+		keys.SetActive(cfg.ActiveKID)
+	}
+	return keys
+}
+
 type generateRequest struct {
 	Action string `json:"action"`
 	TTL    int64  `json:"ttl_seconds"`
@@ -63,7 +159,10 @@ type generateResponse struct {
 	ExpiresAt time.Time `json:"expires_at"`
 }
 
-func handleGenerate(manager *core.Manager, defaultTTL time.Duration) http.HandlerFunc {
+// handleGenerate serves POST /qr/generate. When useKeys is true (SIGNING_KEYS
+// configured, see buildKeySet) it signs through the Manager's KeySet via
+// GenerateV2 instead of the plain secretKey; see core.Manager.GenerateV2.
+func handleGenerate(manager *core.Manager, secret string, useKeys bool, defaultTTL time.Duration) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		uid, ok := r.Context().Value(userIDKey).(string)
 		if !ok || uid == "" {
@@ -81,8 +180,23 @@ func handleGenerate(manager *core.Manager, defaultTTL time.Duration) http.Handle
 			ttl = time.Duration(req.TTL) * time.Second
 		}
 
-		token, payload, err := manager.Generate(r.Context(), uid, req.Action, ttl)
+		var (
+			token   core.Token
+			payload string
+			err     error
+		)
+		if useKeys {
+			token, payload, err = manager.GenerateV2(r.Context(), uid, req.Action, ttl)
+		} else {
+			token, payload, err = manager.Generate(r.Context(), secret, uid, req.Action, ttl)
+		}
 		if err != nil {
+			var rlErr *core.RateLimitError
+			if errors.As(err, &rlErr) {
+				w.Header().Set("Retry-After", strconv.Itoa(int(rlErr.RetryAfter.Seconds())))
+				http.Error(w, err.Error(), http.StatusTooManyRequests)
+				return
+			}
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
@@ -95,6 +209,64 @@ func handleGenerate(manager *core.Manager, defaultTTL time.Duration) http.Handle
 	}
 }
 
+type generateSessionRequest struct {
+	Action            string `json:"action"`
+	AbsoluteTTL       int64  `json:"absolute_ttl_seconds"`
+	IdleTimeoutSecond int64  `json:"idle_timeout_seconds"`
+}
+
+type generateSessionResponse struct {
+	TokenID           string    `json:"token_id"`
+	Payload           string    `json:"payload"`
+	ExpiresAt         time.Time `json:"expires_at"`
+	AbsoluteExpiresAt time.Time `json:"absolute_expires_at"`
+}
+
+// handleGenerateSession serves POST /qr/session, minting a multi-use token
+// meant to be kept alive with POST /qr/touch (see core.Manager.GenerateSession).
+func handleGenerateSession(manager *core.Manager, secret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uid, ok := r.Context().Value(userIDKey).(string)
+		if !ok || uid == "" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var req generateSessionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+		if req.AbsoluteTTL <= 0 || req.IdleTimeoutSecond <= 0 {
+			http.Error(w, "absolute_ttl_seconds and idle_timeout_seconds are required", http.StatusBadRequest)
+			return
+		}
+
+		token, payload, err := manager.GenerateSession(
+			r.Context(), secret, uid, req.Action,
+			time.Duration(req.AbsoluteTTL)*time.Second,
+			time.Duration(req.IdleTimeoutSecond)*time.Second,
+		)
+		if err != nil {
+			var rlErr *core.RateLimitError
+			if errors.As(err, &rlErr) {
+				w.Header().Set("Retry-After", strconv.Itoa(int(rlErr.RetryAfter.Seconds())))
+				http.Error(w, err.Error(), http.StatusTooManyRequests)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		resp := generateSessionResponse{
+			TokenID:           token.ID.String(),
+			Payload:           payload,
+			ExpiresAt:         token.ExpiresAt,
+			AbsoluteExpiresAt: token.AbsoluteExpiresAt,
+		}
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
 type verifyRequest struct {
 	Payload string `json:"payload"`
 }
@@ -107,14 +279,25 @@ type verifyResponse struct {
 	ExpiresAt time.Time `json:"expires_at"`
 }
 
-func handleVerify(manager *core.Manager) http.HandlerFunc {
+// handleVerify serves POST /qr/verify. When useKeys is true it verifies
+// through the Manager's KeySet via VerifyV2 instead of the plain secretKey;
+// see handleGenerate and core.Manager.VerifyV2.
+func handleVerify(manager *core.Manager, secret string, useKeys bool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var req verifyRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Payload == "" {
 			http.Error(w, "invalid request", http.StatusBadRequest)
 			return
 		}
-		token, err := manager.Verify(r.Context(), req.Payload)
+		var (
+			token *core.Token
+			err   error
+		)
+		if useKeys {
+			token, err = manager.VerifyV2(r.Context(), req.Payload)
+		} else {
+			token, err = manager.Verify(r.Context(), secret, req.Payload)
+		}
 		if err != nil {
 			status := http.StatusBadRequest
 			switch err {
@@ -142,6 +325,94 @@ func handleVerify(manager *core.Manager) http.HandlerFunc {
 	}
 }
 
+type touchRequest struct {
+	Payload string `json:"payload"`
+}
+
+type touchResponse struct {
+	TokenID   string    `json:"token_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// handleTouch serves POST /qr/touch, extending a session token's idle
+// deadline (see core.Manager.GenerateSession) without marking it used.
+func handleTouch(manager *core.Manager, secret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req touchRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Payload == "" {
+			http.Error(w, "invalid request", http.StatusBadRequest)
+			return
+		}
+
+		token, err := manager.Touch(r.Context(), secret, req.Payload)
+		if err != nil {
+			status := http.StatusBadRequest
+			switch err {
+			case core.ErrNotFound:
+				status = http.StatusNotFound
+			case core.ErrExpired:
+				status = http.StatusGone
+			case core.ErrBadSignature, core.ErrBadPayload:
+				status = http.StatusUnauthorized
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		resp := touchResponse{
+			TokenID:   token.ID.String(),
+			ExpiresAt: token.ExpiresAt,
+		}
+		writeJSON(w, http.StatusOK, resp)
+	}
+}
+
+type purgeResponse struct {
+	Purged int `json:"purged"`
+}
+
+// handlePurge serves DELETE /qr/tokens?scope=lapsed|user&user_id=...,
+// revoking either expired-but-not-yet-evicted tokens or every outstanding
+// token for a user.
+//
+// scope=lapsed touches every user's tokens, so it requires the admin role
+// claim. scope=user defaults user_id to the caller's own id and only lets
+// an admin operate on someone else's; a non-admin passing a mismatched
+// user_id is rejected rather than silently revoking their own tokens
+// instead.
+func handlePurge(manager *core.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		uid, _ := r.Context().Value(userIDKey).(string)
+		isAdmin, _ := r.Context().Value(isAdminKey).(bool)
+
+		scope := core.PurgeScope(r.URL.Query().Get("scope"))
+		userID := r.URL.Query().Get("user_id")
+
+		switch scope {
+		case core.PurgeScopeLapsed:
+			if !isAdmin {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+		case core.PurgeScopeUser:
+			if userID == "" {
+				userID = uid
+			}
+			if !isAdmin && userID != uid {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+		}
+
+		purged, err := manager.Purge(r.Context(), core.PurgeOptions{Scope: scope, UserID: userID})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, http.StatusOK, purgeResponse{Purged: purged})
+	}
+}
+
 // --- middleware & helpers ---
 
 func loggingMiddleware(next http.Handler) http.Handler {
@@ -196,18 +467,23 @@ func jwtAuth(secret string) func(http.Handler) http.Handler {
 				return
 			}
 			raw := strings.TrimSpace(auth[7:])
-			userID, err := parseAndValidateJWT(raw, secret)
+			userID, isAdmin, err := parseAndValidateJWT(raw, secret)
 			if err != nil {
 				http.Error(w, "invalid token", http.StatusUnauthorized)
 				return
 			}
 			ctx := context.WithValue(r.Context(), userIDKey, userID)
+			ctx = context.WithValue(ctx, isAdminKey, isAdmin)
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
-func parseAndValidateJWT(tokenStr, secret string) (string, error) {
+// parseAndValidateJWT returns the caller's user id (the "sub" claim) and
+// whether the token carries a "role":"admin" claim. Admin is opt-in and
+// only meaningful for endpoints, like handlePurge, that need to act on
+// another user's data.
+func parseAndValidateJWT(tokenStr, secret string) (userID string, isAdmin bool, err error) {
 	tok, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, errors.New("unexpected signing method")
@@ -215,17 +491,18 @@ func parseAndValidateJWT(tokenStr, secret string) (string, error) {
 		return []byte(secret), nil
 	})
 	if err != nil || !tok.Valid {
-		return "", errors.New("invalid jwt")
+		return "", false, errors.New("invalid jwt")
 	}
 	claims, ok := tok.Claims.(jwt.MapClaims)
 	if !ok {
-		return "", errors.New("invalid claims")
+		return "", false, errors.New("invalid claims")
 	}
 	sub, _ := claims["sub"].(string)
 	if sub == "" {
-		return "", errors.New("missing sub")
+		return "", false, errors.New("missing sub")
 	}
-	return sub, nil
+	role, _ := claims["role"].(string)
+	return sub, role == "admin", nil
 }
 
 func writeJSON(w http.ResponseWriter, status int, v any) {
@@ -242,6 +519,21 @@ type config struct {
 	Port       int
 	DefaultTTL time.Duration
 	RedisAddr  string
+	// StoreDriver/StoreDSN select a Store registered via core.RegisterStore
+	// (e.g. "postgres") instead of the default memory/Redis choice. See
+	// core.ManagerOptions.
+	StoreDriver string
+	StoreDSN    string
+	// OIDCIssuer/OIDCAudience, if set, switch request authentication from
+	// jwtAuth to an OIDC connector trusting that issuer. See
+	// core/auth/oidc.
+	OIDCIssuer   string
+	OIDCAudience string
+	// SigningKeys/ActiveKID, if set, enable GenerateV2/VerifyV2 via a
+	// core.KeySet instead of the plain secretKey-based Generate/Verify. See
+	// buildKeySet.
+	SigningKeys string
+	ActiveKID   string
 }
 
 func loadConfig() config {
@@ -258,11 +550,17 @@ func loadConfig() config {
 		}
 	}
 	return config{
-		HMACSecret: envOr("QR_HMAC_SECRET", "dev-hmac-secret-change-me"),
-		JWTSecret:  envOr("JWT_SECRET", "dev-jwt-secret-change-me"),
-		Port:       port,
-		DefaultTTL: ttl,
-		RedisAddr:  os.Getenv("REDIS_ADDR"),
+		HMACSecret:   envOr("QR_HMAC_SECRET", "dev-hmac-secret-change-me"),
+		JWTSecret:    envOr("JWT_SECRET", "dev-jwt-secret-change-me"),
+		Port:         port,
+		DefaultTTL:   ttl,
+		RedisAddr:    os.Getenv("REDIS_ADDR"),
+		StoreDriver:  os.Getenv("STORE_DRIVER"),
+		StoreDSN:     os.Getenv("STORE_DSN"),
+		OIDCIssuer:   os.Getenv("OIDC_ISSUER"),
+		OIDCAudience: os.Getenv("OIDC_AUDIENCE"),
+		SigningKeys:  os.Getenv("SIGNING_KEYS"),
+		ActiveKID:    os.Getenv("ACTIVE_KID"),
 	}
 }
 
@@ -272,18 +570,3 @@ func envOr(key, def string) string {
 	}
 	return def
 }
-
-func buildStore(cfg config) core.Store {
-	if cfg.RedisAddr == "" {
-		log.Printf("using in-memory store")
-		return core.NewMemoryStore()
-	}
-	opts := &redis.Options{Addr: cfg.RedisAddr}
-	client := redis.NewClient(opts)
-	if err := client.Ping(context.Background()).Err(); err != nil {
-		log.Fatalf("redis ping failed: %v", err)
-	}
-	log.Printf("using redis store at %s", cfg.RedisAddr)
-	return core.NewRedisStore(client, "qr-token:")
-}
-
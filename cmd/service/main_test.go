@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/tunaaoguzhann/qr-access/core"
+)
+
+func purgeRequest(t *testing.T, uid string, isAdmin bool, query string) *http.Request {
+	t.Helper()
+	r := httptest.NewRequest(http.MethodDelete, "/qr/tokens?"+query, nil)
+	ctx := context.WithValue(r.Context(), userIDKey, uid)
+	ctx = context.WithValue(ctx, isAdminKey, isAdmin)
+	return r.WithContext(ctx)
+}
+
+func newTestManager(t *testing.T) *core.Manager {
+	t.Helper()
+	manager, err := core.NewManagerWithOptions(core.ManagerOptions{})
+	if err != nil {
+		t.Fatalf("NewManagerWithOptions: %v", err)
+	}
+	return manager
+}
+
+func TestHandlePurgeRejectsNonAdminLapsedScope(t *testing.T) {
+	handler := handlePurge(newTestManager(t))
+	w := httptest.NewRecorder()
+	handler(w, purgeRequest(t, "alice", false, "scope=lapsed"))
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("non-admin scope=lapsed: got status %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
+
+func TestHandlePurgeAllowsAdminLapsedScope(t *testing.T) {
+	handler := handlePurge(newTestManager(t))
+	w := httptest.NewRecorder()
+	handler(w, purgeRequest(t, "alice", true, "scope=lapsed"))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("admin scope=lapsed: got status %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestHandlePurgeRejectsNonAdminPurgingAnotherUser(t *testing.T) {
+	handler := handlePurge(newTestManager(t))
+	w := httptest.NewRecorder()
+	handler(w, purgeRequest(t, "alice", false, "scope=user&user_id=bob"))
+
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("non-admin scope=user for another user: got status %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
+
+func TestHandlePurgeAllowsNonAdminPurgingSelf(t *testing.T) {
+	handler := handlePurge(newTestManager(t))
+	w := httptest.NewRecorder()
+	handler(w, purgeRequest(t, "alice", false, "scope=user&user_id=alice"))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("non-admin scope=user for self: got status %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestHandlePurgeAllowsAdminPurgingAnotherUser(t *testing.T) {
+	handler := handlePurge(newTestManager(t))
+	w := httptest.NewRecorder()
+	handler(w, purgeRequest(t, "alice", true, "scope=user&user_id=bob"))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("admin scope=user for another user: got status %d, want %d", w.Code, http.StatusOK)
+	}
+}
@@ -0,0 +1,34 @@
+// Package auth provides a pluggable authentication subsystem for qr-access.
+// The existing jwtAuth middleware in cmd/service only accepts a pre-issued
+// HS256 JWT signed with a shared secret; Authenticator lets qr-access
+// integrate with real identity providers instead (see the oidc and github
+// subpackages).
+package auth
+
+import (
+	"context"
+	"net/http"
+)
+
+// Identity is the internal representation of an authenticated caller,
+// resolved from whatever claims or profile fields the identity provider
+// returned.
+type Identity struct {
+	UserID string
+	Email  string
+	Name   string
+}
+
+// Authenticator validates a credential extracted from an inbound request and
+// resolves it to an Identity.
+type Authenticator interface {
+	Authenticate(ctx context.Context, r *http.Request) (Identity, error)
+}
+
+// ClaimMapping names which token claims (or provider profile fields) populate
+// an Identity. Empty fields fall back to each connector's own defaults.
+type ClaimMapping struct {
+	UserID string
+	Email  string
+	Name   string
+}
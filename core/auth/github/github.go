@@ -0,0 +1,162 @@
+// Package github is a GitHub OAuth2 connector for core/auth. Unlike the oidc
+// connector it doesn't verify a bearer token on every request; it drives the
+// authorization-code flow (redirect to GitHub, exchange the returned code for
+// an access token, fetch the user profile) and hands the resulting identity
+// to a callback.
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/tunaaoguzhann/qr-access/core/auth"
+)
+
+const (
+	authorizeURL = "https://github.com/login/oauth/authorize"
+	tokenURL     = "https://github.com/login/oauth/access_token"
+	userURL      = "https://api.github.com/user"
+)
+
+// Connector drives the GitHub OAuth2 authorization-code flow.
+type Connector struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+
+	httpClient *http.Client
+}
+
+// NewConnector builds a Connector for the given OAuth app credentials.
+func NewConnector(clientID, clientSecret, redirectURL string) *Connector {
+	return &Connector{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+		httpClient:   http.DefaultClient,
+	}
+}
+
+// AuthCodeURL returns the URL to redirect the user to in order to start the
+// flow. state should be an opaque, unguessable value the caller verifies on
+// callback to prevent CSRF.
+func (c *Connector) AuthCodeURL(state string) string {
+	v := url.Values{
+		"client_id":    {c.ClientID},
+		"redirect_uri": {c.RedirectURL},
+		"state":        {state},
+		"scope":        {"read:user user:email"},
+	}
+	return authorizeURL + "?" + v.Encode()
+}
+
+// CallbackHandler returns an http.HandlerFunc for the OAuth redirect_uri: it
+// exchanges the ?code= for an access token, fetches the GitHub user profile,
+// and invokes onIdentity with the resolved auth.Identity. Callers are
+// responsible for validating the ?state= parameter before the handler runs.
+func (c *Connector) CallbackHandler(onIdentity func(w http.ResponseWriter, r *http.Request, identity auth.Identity)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		code := r.URL.Query().Get("code")
+		if code == "" {
+			http.Error(w, "missing code", http.StatusBadRequest)
+			return
+		}
+
+		accessToken, err := c.exchangeCode(r.Context(), code)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		identity, err := c.fetchIdentity(r.Context(), accessToken)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		onIdentity(w, r, identity)
+	}
+}
+
+func (c *Connector) exchangeCode(ctx context.Context, code string) (string, error) {
+	body := url.Values{
+		"client_id":     {c.ClientID},
+		"client_secret": {c.ClientSecret},
+		"code":          {code},
+		"redirect_uri":  {c.RedirectURL},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(body.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("github: exchange code: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var out struct {
+		AccessToken string `json:"access_token"`
+		Error       string `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", fmt.Errorf("github: decode token response: %w", err)
+	}
+	if out.Error != "" {
+		return "", fmt.Errorf("github: %s", out.Error)
+	}
+	if out.AccessToken == "" {
+		return "", fmt.Errorf("github: empty access token")
+	}
+	return out.AccessToken, nil
+}
+
+func (c *Connector) fetchIdentity(ctx context.Context, accessToken string) (auth.Identity, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, userURL, nil)
+	if err != nil {
+		return auth.Identity{}, err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return auth.Identity{}, fmt.Errorf("github: fetch user: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return auth.Identity{}, fmt.Errorf("github: unexpected status %d fetching user", resp.StatusCode)
+	}
+
+	var profile struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+		Email string `json:"email"`
+		Name  string `json:"name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&profile); err != nil {
+		return auth.Identity{}, fmt.Errorf("github: decode user: %w", err)
+	}
+
+	return auth.Identity{
+		UserID: fmt.Sprintf("github:%d", profile.ID),
+		Email:  profile.Email,
+		Name:   coalesce(profile.Name, profile.Login),
+	}, nil
+}
+
+func coalesce(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,370 @@
+// Package oidc is an OIDC connector for core/auth: it discovers a provider
+// via its .well-known/openid-configuration document, caches the provider's
+// JWKS, verifies RS256/ES256 tokens by kid, and maps configured claims onto
+// the internal user identity. Multiple issuers can be registered on one
+// Connector, keyed by the token's iss claim.
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"github.com/tunaaoguzhann/qr-access/core/auth"
+)
+
+// IssuerConfig configures one trusted OIDC provider.
+type IssuerConfig struct {
+	// Issuer is the provider's issuer URL, matched against the token's iss
+	// claim and used to build the discovery document URL
+	// (Issuer + "/.well-known/openid-configuration").
+	Issuer string
+	// Audience, if set, must appear in the token's aud claim.
+	Audience string
+	// ClaimMapping names the claims used to populate auth.Identity. Unset
+	// fields default to "sub", "email", and "preferred_username".
+	ClaimMapping auth.ClaimMapping
+}
+
+func (c IssuerConfig) claim(name string) string {
+	switch name {
+	case "user_id":
+		if c.ClaimMapping.UserID != "" {
+			return c.ClaimMapping.UserID
+		}
+		return "sub"
+	case "email":
+		if c.ClaimMapping.Email != "" {
+			return c.ClaimMapping.Email
+		}
+		return "email"
+	case "name":
+		if c.ClaimMapping.Name != "" {
+			return c.ClaimMapping.Name
+		}
+		return "preferred_username"
+	default:
+		return name
+	}
+}
+
+// Connector is an auth.Authenticator backed by one or more OIDC issuers.
+type Connector struct {
+	httpClient *http.Client
+
+	mu      sync.RWMutex
+	issuers map[string]IssuerConfig
+	jwks    map[string]*jwksCache // keyed by issuer
+}
+
+// NewConnector builds a Connector trusting the given issuers.
+func NewConnector(issuers ...IssuerConfig) *Connector {
+	c := &Connector{
+		httpClient: http.DefaultClient,
+		issuers:    make(map[string]IssuerConfig),
+		jwks:       make(map[string]*jwksCache),
+	}
+	for _, cfg := range issuers {
+		c.issuers[cfg.Issuer] = cfg
+	}
+	return c
+}
+
+// Authenticate verifies the bearer token in the request's Authorization
+// header against whichever registered issuer it claims, and maps its claims
+// to an identity.
+func (c *Connector) Authenticate(ctx context.Context, r *http.Request) (auth.Identity, error) {
+	raw, err := bearerToken(r)
+	if err != nil {
+		return auth.Identity{}, err
+	}
+
+	unverified, _, err := jwt.NewParser().ParseUnverified(raw, jwt.MapClaims{})
+	if err != nil {
+		return auth.Identity{}, fmt.Errorf("oidc: parse token: %w", err)
+	}
+	claims, ok := unverified.Claims.(jwt.MapClaims)
+	if !ok {
+		return auth.Identity{}, fmt.Errorf("oidc: invalid claims")
+	}
+	iss, _ := claims["iss"].(string)
+
+	c.mu.RLock()
+	cfg, ok := c.issuers[iss]
+	c.mu.RUnlock()
+	if !ok {
+		return auth.Identity{}, fmt.Errorf("oidc: untrusted issuer %q", iss)
+	}
+
+	keys, err := c.keysFor(ctx, cfg.Issuer)
+	if err != nil {
+		return auth.Identity{}, err
+	}
+
+	// A kid the cache doesn't recognize might just mean the provider rotated
+	// its signing key since our last refresh (at most jwksRefreshInterval
+	// ago) — force one refetch before concluding the kid is genuinely
+	// unknown, so a legitimate rotation doesn't lock out new tokens for up
+	// to jwksRefreshInterval.
+	if kid, _ := unverified.Header["kid"].(string); kid != "" {
+		if _, ok := keys.key(kid); !ok {
+			keys, err = c.refreshKeys(ctx, cfg.Issuer)
+			if err != nil {
+				return auth.Identity{}, err
+			}
+		}
+	}
+
+	token, err := jwt.Parse(raw, func(t *jwt.Token) (interface{}, error) {
+		switch t.Method.(type) {
+		case *jwt.SigningMethodRSA:
+		case *jwt.SigningMethodECDSA:
+		default:
+			return nil, fmt.Errorf("oidc: unsupported signing method %v", t.Method)
+		}
+		kid, _ := t.Header["kid"].(string)
+		key, ok := keys.key(kid)
+		if !ok {
+			return nil, fmt.Errorf("oidc: unknown kid %q", kid)
+		}
+		return key, nil
+	})
+	if err != nil || !token.Valid {
+		return auth.Identity{}, fmt.Errorf("oidc: invalid token: %w", err)
+	}
+
+	verifiedClaims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return auth.Identity{}, fmt.Errorf("oidc: invalid claims")
+	}
+
+	if cfg.Audience != "" && !hasAudience(verifiedClaims, cfg.Audience) {
+		return auth.Identity{}, fmt.Errorf("oidc: token not issued for audience %q", cfg.Audience)
+	}
+
+	userID, _ := verifiedClaims[cfg.claim("user_id")].(string)
+	if userID == "" {
+		return auth.Identity{}, fmt.Errorf("oidc: missing %q claim", cfg.claim("user_id"))
+	}
+	email, _ := verifiedClaims[cfg.claim("email")].(string)
+	name, _ := verifiedClaims[cfg.claim("name")].(string)
+
+	return auth.Identity{UserID: userID, Email: email, Name: name}, nil
+}
+
+func hasAudience(claims jwt.MapClaims, audience string) bool {
+	switch aud := claims["aud"].(type) {
+	case string:
+		return aud == audience
+	case []interface{}:
+		for _, a := range aud {
+			if s, _ := a.(string); s == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func bearerToken(r *http.Request) (string, error) {
+	header := r.Header.Get("Authorization")
+	const prefix = "bearer "
+	if len(header) <= len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return "", fmt.Errorf("oidc: missing bearer token")
+	}
+	return strings.TrimSpace(header[len(prefix):]), nil
+}
+
+// --- discovery & JWKS caching ---
+
+type discoveryDocument struct {
+	JWKSURI string `json:"jwks_uri"`
+}
+
+type jsonWebKeySet struct {
+	Keys []jsonWebKey `json:"keys"`
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Crv string `json:"crv"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// jwksCache holds a provider's parsed public keys, refreshed once they are
+// older than jwksRefreshInterval, or earlier when a token names a kid the
+// cache doesn't hold (see refreshKeys), so a verification doesn't pay a
+// discovery round trip on every request.
+type jwksCache struct {
+	mu        sync.RWMutex
+	keys      map[string]interface{}
+	fetchedAt time.Time
+	jwksURI   string
+}
+
+const jwksRefreshInterval = 10 * time.Minute
+
+func (j *jwksCache) key(kid string) (interface{}, bool) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	k, ok := j.keys[kid]
+	return k, ok
+}
+
+func (c *Connector) keysFor(ctx context.Context, issuer string) (*jwksCache, error) {
+	c.mu.Lock()
+	cache, ok := c.jwks[issuer]
+	if !ok {
+		cache = &jwksCache{}
+		c.jwks[issuer] = cache
+	}
+	c.mu.Unlock()
+
+	cache.mu.RLock()
+	stale := time.Since(cache.fetchedAt) > jwksRefreshInterval
+	cache.mu.RUnlock()
+	if !stale {
+		return cache, nil
+	}
+
+	return c.refreshKeys(ctx, issuer)
+}
+
+// refreshKeys refetches issuer's JWKS unconditionally, bypassing
+// jwksRefreshInterval. keysFor calls it when the cache is stale on a normal
+// timer; Authenticate also calls it directly, once, when a token's kid isn't
+// in the (possibly still-fresh) cache, since that's what a provider's key
+// rotation looks like from here.
+func (c *Connector) refreshKeys(ctx context.Context, issuer string) (*jwksCache, error) {
+	c.mu.Lock()
+	cache, ok := c.jwks[issuer]
+	if !ok {
+		cache = &jwksCache{}
+		c.jwks[issuer] = cache
+	}
+	c.mu.Unlock()
+
+	doc, err := c.fetchDiscoveryDocument(ctx, issuer)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := c.fetchJWKS(ctx, doc.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+
+	cache.mu.Lock()
+	cache.keys = keys
+	cache.jwksURI = doc.JWKSURI
+	cache.fetchedAt = time.Now()
+	cache.mu.Unlock()
+	return cache, nil
+}
+
+func (c *Connector) fetchDiscoveryDocument(ctx context.Context, issuer string) (discoveryDocument, error) {
+	var doc discoveryDocument
+	url := strings.TrimSuffix(issuer, "/") + "/.well-known/openid-configuration"
+	if err := c.getJSON(ctx, url, &doc); err != nil {
+		return doc, fmt.Errorf("oidc: discover %s: %w", issuer, err)
+	}
+	return doc, nil
+}
+
+func (c *Connector) fetchJWKS(ctx context.Context, jwksURI string) (map[string]interface{}, error) {
+	var set jsonWebKeySet
+	if err := c.getJSON(ctx, jwksURI, &set); err != nil {
+		return nil, fmt.Errorf("oidc: fetch jwks %s: %w", jwksURI, err)
+	}
+
+	keys := make(map[string]interface{}, len(set.Keys))
+	for _, jwk := range set.Keys {
+		key, err := jwk.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	return keys, nil
+}
+
+func (c *Connector) getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	case "EC":
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		curve, err := ellipticCurve(k.Crv)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}, nil
+	default:
+		return nil, fmt.Errorf("oidc: unsupported key type %q", k.Kty)
+	}
+}
+
+func ellipticCurve(name string) (elliptic.Curve, error) {
+	switch name {
+	case "P-256":
+		return elliptic.P256(), nil
+	case "P-384":
+		return elliptic.P384(), nil
+	case "P-521":
+		return elliptic.P521(), nil
+	default:
+		return nil, fmt.Errorf("oidc: unsupported curve %q", name)
+	}
+}
@@ -9,10 +9,18 @@ import (
 type ManagerOptions struct {
 	RedisAddr      string
 	RedisKeyPrefix string
-	MinTTL         time.Duration
-	MaxTTL         time.Duration
-	RateLimit      int
-	RateWindow     time.Duration
+	// StoreDriver selects a Store registered via RegisterStore (e.g.
+	// "postgres"), constructed from StoreDSN. It takes precedence over
+	// RedisAddr so a driver-backed store can be chosen explicitly.
+	StoreDriver string
+	StoreDSN    string
+	MinTTL      time.Duration
+	MaxTTL      time.Duration
+	RateLimit   int
+	RateWindow  time.Duration
+	// Keys, if set, enables GenerateV2/VerifyV2 (see Config.Keys). Without it
+	// the Manager only supports the v1 secretKey-based Generate/Verify.
+	Keys *KeySet
 }
 
 func NewManager() (*Manager, error) {
@@ -23,7 +31,20 @@ func NewManagerWithOptions(opts ManagerOptions) (*Manager, error) {
 	var store Store
 	var rateLimiter RateLimiter
 
-	if opts.RedisAddr != "" {
+	switch {
+	case opts.StoreDriver != "":
+		s, err := NewStore(opts.StoreDriver, opts.StoreDSN)
+		if err != nil {
+			return nil, err
+		}
+		store = s
+		if opts.RedisAddr != "" && opts.RateLimit > 0 {
+			client := redis.NewClient(&redis.Options{Addr: opts.RedisAddr})
+			rateLimiter = NewRedisRateLimiter(client, "qr-rate:")
+		} else if opts.RateLimit > 0 {
+			rateLimiter = NewMemoryRateLimiter()
+		}
+	case opts.RedisAddr != "":
 		client := redis.NewClient(&redis.Options{
 			Addr: opts.RedisAddr,
 		})
@@ -35,7 +56,7 @@ func NewManagerWithOptions(opts ManagerOptions) (*Manager, error) {
 		if opts.RateLimit > 0 {
 			rateLimiter = NewRedisRateLimiter(client, "qr-rate:")
 		}
-	} else {
+	default:
 		store = NewMemoryStore()
 		if opts.RateLimit > 0 {
 			rateLimiter = NewMemoryRateLimiter()
@@ -54,6 +75,7 @@ func NewManagerWithOptions(opts ManagerOptions) (*Manager, error) {
 		RateLimiter: rateLimiter,
 		RateLimit:   opts.RateLimit,
 		RateWindow:  rateWindow,
+		Keys:        opts.Keys,
 	}
 	return newManager(cfg)
 }
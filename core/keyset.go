@@ -0,0 +1,44 @@
+package core
+
+// KeySet maps a key id (kid) to the Signer that should sign or verify
+// payloads tagged with it. It lets Manager.VerifyV2 pick the right key by kid
+// instead of trusting a single shared secret, so rotating to a new key
+// doesn't invalidate outstanding tokens signed under the old one.
+type KeySet struct {
+	signers map[string]Signer
+	active  string
+}
+
+// NewKeySet builds an empty KeySet. Add keys with AddKey.
+func NewKeySet() *KeySet {
+	return &KeySet{signers: make(map[string]Signer)}
+}
+
+// AddKey registers signer under kid. The first key added becomes the active
+// key used to sign new payloads; call SetActive to change it later, e.g. once
+// a newly-rotated-in key has propagated everywhere that verifies tokens.
+func (k *KeySet) AddKey(kid string, signer Signer) *KeySet {
+	k.signers[kid] = signer
+	if k.active == "" {
+		k.active = kid
+	}
+	return k
+}
+
+// SetActive changes which registered kid is used to sign new payloads.
+func (k *KeySet) SetActive(kid string) *KeySet {
+	k.active = kid
+	return k
+}
+
+// Active returns the kid and Signer currently used for new payloads.
+func (k *KeySet) Active() (kid string, signer Signer, ok bool) {
+	signer, ok = k.signers[k.active]
+	return k.active, signer, ok
+}
+
+// Get looks up the Signer registered under kid.
+func (k *KeySet) Get(kid string) (Signer, bool) {
+	signer, ok := k.signers[kid]
+	return signer, ok
+}
@@ -2,6 +2,7 @@ package core
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -16,6 +17,7 @@ type Manager struct {
 	rateLimiter RateLimiter
 	rateLimit   int
 	rateWindow  time.Duration
+	keys        *KeySet
 }
 
 type Config struct {
@@ -26,6 +28,10 @@ type Config struct {
 	RateLimiter RateLimiter
 	RateLimit   int
 	RateWindow  time.Duration
+	// Keys, if set, enables GenerateV2/VerifyV2: payloads signed and
+	// verified by kid instead of a single shared secret, so rotating in a
+	// new key doesn't invalidate outstanding tokens.
+	Keys *KeySet
 }
 
 func newManager(cfg Config) (*Manager, error) {
@@ -44,6 +50,7 @@ func newManager(cfg Config) (*Manager, error) {
 		rateLimiter: cfg.RateLimiter,
 		rateLimit:   cfg.RateLimit,
 		rateWindow:  cfg.RateWindow,
+		keys:        cfg.Keys,
 	}, nil
 }
 
@@ -59,7 +66,11 @@ func (m *Manager) Generate(ctx context.Context, secretKey, userID, action string
 	}
 
 	if m.rateLimiter != nil && m.rateLimit > 0 {
-		if err := m.rateLimiter.CheckAndIncrement(ctx, userID, m.rateLimit, m.rateWindow); err != nil {
+		retryAfter, err := m.rateLimiter.CheckAndIncrement(ctx, userID, m.rateLimit, m.rateWindow)
+		if err != nil {
+			if errors.Is(err, ErrRateLimitExceeded) {
+				return Token{}, "", &RateLimitError{RetryAfter: retryAfter}
+			}
 			return Token{}, "", err
 		}
 	}
@@ -114,6 +125,229 @@ func (m *Manager) Verify(ctx context.Context, secretKey, encoded string) (*Token
 		return nil, ErrBadSignature
 	}
 
+	return m.loadAndMarkUsed(ctx, tokenID)
+}
+
+// GenerateV2 behaves like Generate but signs the payload with the active key
+// in the Manager's KeySet and encodes it in the v2 wire format, so rotating
+// the active key doesn't invalidate tokens signed under a previous kid.
+func (m *Manager) GenerateV2(ctx context.Context, userID, action string, ttl time.Duration) (Token, string, error) {
+	if m.keys == nil {
+		return Token{}, "", fmt.Errorf("keyset is required")
+	}
+	if userID == "" || action == "" {
+		return Token{}, "", fmt.Errorf("userID and action are required")
+	}
+	if ttl <= 0 {
+		return Token{}, "", fmt.Errorf("ttl must be positive")
+	}
+
+	if m.rateLimiter != nil && m.rateLimit > 0 {
+		retryAfter, err := m.rateLimiter.CheckAndIncrement(ctx, userID, m.rateLimit, m.rateWindow)
+		if err != nil {
+			if errors.Is(err, ErrRateLimitExceeded) {
+				return Token{}, "", &RateLimitError{RetryAfter: retryAfter}
+			}
+			return Token{}, "", err
+		}
+	}
+
+	if m.maxTTL > 0 && ttl > m.maxTTL {
+		ttl = m.maxTTL
+	}
+	if m.minTTL > 0 && ttl < m.minTTL {
+		ttl = m.minTTL
+	}
+
+	kid, signer, ok := m.keys.Active()
+	if !ok {
+		return Token{}, "", fmt.Errorf("no active signing key")
+	}
+
+	id := uuid.New()
+	now := m.now()
+	token := Token{
+		ID:        id,
+		UserID:    userID,
+		Action:    action,
+		ExpiresAt: now.Add(ttl),
+		Used:      false,
+	}
+
+	if err := m.store.Save(ctx, token, ttl); err != nil {
+		return Token{}, "", err
+	}
+
+	signature := signer.Sign(id[:])
+	payload, err := EncodePayloadV2(kid, signer.Algorithm(), id.String(), signature)
+	if err != nil {
+		return Token{}, "", err
+	}
+	return token, payload, nil
+}
+
+// VerifyV2 verifies a payload produced by GenerateV2, picking the Signer in
+// the Manager's KeySet by the payload's kid. It also accepts v1 payloads for
+// backward compatibility: register the legacy HMAC secret in the KeySet
+// under the reserved kid "v1" to keep verifying tokens issued before
+// adopting key rotation.
+func (m *Manager) VerifyV2(ctx context.Context, encoded string) (*Token, error) {
+	if m.keys == nil {
+		return nil, fmt.Errorf("keyset is required")
+	}
+
+	data, err := DecodePayload(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenID, err := uuid.Parse(data.ID)
+	if err != nil {
+		return nil, ErrBadPayload
+	}
+
+	kid := data.Kid
+	if data.Version == "v1" {
+		kid = "v1"
+	}
+	signer, ok := m.keys.Get(kid)
+	if !ok {
+		return nil, ErrBadSignature
+	}
+	if ok := signer.Verify(tokenID[:], data.Sig); !ok {
+		return nil, ErrBadSignature
+	}
+
+	return m.loadAndMarkUsed(ctx, tokenID)
+}
+
+// GenerateSession produces a multi-use token bounded by an absolute deadline
+// and an idle timeout, for long-lived use cases like a persistent access
+// badge QR that stays valid as long as it's scanned periodically. Unlike
+// Generate, the returned token is never marked used; callers extend its life
+// with Touch before idleTimeout elapses.
+func (m *Manager) GenerateSession(ctx context.Context, secretKey, userID, action string, absoluteTTL, idleTimeout time.Duration) (Token, string, error) {
+	if secretKey == "" {
+		return Token{}, "", fmt.Errorf("secret key is required")
+	}
+	if userID == "" || action == "" {
+		return Token{}, "", fmt.Errorf("userID and action are required")
+	}
+	if absoluteTTL <= 0 || idleTimeout <= 0 {
+		return Token{}, "", fmt.Errorf("absoluteTTL and idleTimeout must be positive")
+	}
+
+	if m.rateLimiter != nil && m.rateLimit > 0 {
+		retryAfter, err := m.rateLimiter.CheckAndIncrement(ctx, userID, m.rateLimit, m.rateWindow)
+		if err != nil {
+			if errors.Is(err, ErrRateLimitExceeded) {
+				return Token{}, "", &RateLimitError{RetryAfter: retryAfter}
+			}
+			return Token{}, "", err
+		}
+	}
+
+	id := uuid.New()
+	now := m.now()
+	absoluteExpiresAt := now.Add(absoluteTTL)
+	expiresAt := now.Add(idleTimeout)
+	if expiresAt.After(absoluteExpiresAt) {
+		expiresAt = absoluteExpiresAt
+	}
+
+	token := Token{
+		ID:                id,
+		UserID:            userID,
+		Action:            action,
+		ExpiresAt:         expiresAt,
+		AbsoluteExpiresAt: absoluteExpiresAt,
+		IdleTimeout:       idleTimeout,
+		Used:              false,
+	}
+
+	if err := m.store.Save(ctx, token, absoluteTTL); err != nil {
+		return Token{}, "", err
+	}
+
+	signer := NewSigner(secretKey)
+	signature := signer.Sign(id[:])
+	payload, err := EncodePayload(id.String(), signature)
+	if err != nil {
+		return Token{}, "", err
+	}
+	return token, payload, nil
+}
+
+// Touch extends a session token's idle deadline (capped at its absolute
+// expiry) without marking it used. It returns ErrBadPayload/ErrBadSignature
+// for the same reasons as Verify, and requires the Store to implement
+// Toucher.
+func (m *Manager) Touch(ctx context.Context, secretKey, encoded string) (*Token, error) {
+	if secretKey == "" {
+		return nil, fmt.Errorf("secret key is required")
+	}
+
+	data, err := DecodePayload(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	tokenID, err := uuid.Parse(data.ID)
+	if err != nil {
+		return nil, ErrBadPayload
+	}
+
+	signer := NewSigner(secretKey)
+	if ok := signer.Verify(tokenID[:], data.Sig); !ok {
+		return nil, ErrBadSignature
+	}
+
+	toucher, ok := m.store.(Toucher)
+	if !ok {
+		return nil, fmt.Errorf("store does not support idle-timeout sessions")
+	}
+	return toucher.Touch(ctx, tokenID, m.now())
+}
+
+// PurgeScope selects what Manager.Purge removes.
+type PurgeScope string
+
+const (
+	// PurgeScopeLapsed sweeps expired-but-not-yet-evicted tokens. Required
+	// for backends like Postgres that don't expire rows on their own; the
+	// Store must implement LapsedPurger.
+	PurgeScopeLapsed PurgeScope = "lapsed"
+	// PurgeScopeUser revokes every outstanding token for PurgeOptions.UserID,
+	// e.g. when a device is lost.
+	PurgeScopeUser PurgeScope = "user"
+)
+
+type PurgeOptions struct {
+	Scope  PurgeScope
+	UserID string
+}
+
+// Purge removes tokens from the store per opts.Scope and returns the count
+// removed.
+func (m *Manager) Purge(ctx context.Context, opts PurgeOptions) (int, error) {
+	switch opts.Scope {
+	case PurgeScopeLapsed:
+		purger, ok := m.store.(LapsedPurger)
+		if !ok {
+			return 0, fmt.Errorf("store does not support lapsed purge")
+		}
+		return purger.PurgeLapsed(ctx, m.now())
+	case PurgeScopeUser:
+		if opts.UserID == "" {
+			return 0, fmt.Errorf("user_id is required for user scope")
+		}
+		return m.store.DeleteByUser(ctx, opts.UserID)
+	default:
+		return 0, fmt.Errorf("unknown purge scope %q", opts.Scope)
+	}
+}
+
+func (m *Manager) loadAndMarkUsed(ctx context.Context, tokenID uuid.UUID) (*Token, error) {
 	token, err := m.store.Get(ctx, tokenID)
 	if err != nil {
 		return nil, err
@@ -127,10 +361,15 @@ func (m *Manager) Verify(ctx context.Context, secretKey, encoded string) (*Token
 		return nil, ErrUsed
 	}
 
+	// Session tokens (from GenerateSession) are multi-use by design: their
+	// idle deadline is managed by Touch, not by single-use consumption here.
+	if token.IdleTimeout > 0 {
+		return token, nil
+	}
+
 	if err := m.store.MarkUsed(ctx, tokenID); err != nil {
 		return nil, err
 	}
 	token.Used = true
 	return token, nil
 }
-
@@ -0,0 +1,118 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newManagerAt(t *testing.T, now time.Time, keys *KeySet) *Manager {
+	t.Helper()
+	m, err := newManager(Config{
+		Store: NewMemoryStore(),
+		Now:   func() time.Time { return now },
+		Keys:  keys,
+	})
+	if err != nil {
+		t.Fatalf("newManager: %v", err)
+	}
+	return m
+}
+
+func TestManagerTouchExtendsAndCapsAtAbsoluteExpiry(t *testing.T) {
+	ctx := context.Background()
+	start := time.Unix(0, 0)
+	m := newManagerAt(t, start, nil)
+
+	const secret = "session-secret"
+	token, payload, err := m.GenerateSession(ctx, secret, "alice", "checkin", 5*time.Minute, time.Minute)
+	if err != nil {
+		t.Fatalf("GenerateSession: %v", err)
+	}
+
+	// A touch well inside the idle window extends ExpiresAt by IdleTimeout.
+	m.now = func() time.Time { return start.Add(30 * time.Second) }
+	touched, err := m.Touch(ctx, secret, payload)
+	if err != nil {
+		t.Fatalf("Touch: %v", err)
+	}
+	wantExpiresAt := start.Add(30 * time.Second).Add(time.Minute)
+	if !touched.ExpiresAt.Equal(wantExpiresAt) {
+		t.Fatalf("ExpiresAt = %v, want %v", touched.ExpiresAt, wantExpiresAt)
+	}
+
+	// A touch close to the absolute deadline is capped there instead of
+	// extending past it.
+	m.now = func() time.Time { return token.AbsoluteExpiresAt.Add(-10 * time.Second) }
+	touched, err = m.Touch(ctx, secret, payload)
+	if err != nil {
+		t.Fatalf("Touch near absolute deadline: %v", err)
+	}
+	if !touched.ExpiresAt.Equal(token.AbsoluteExpiresAt) {
+		t.Fatalf("ExpiresAt = %v, want capped at AbsoluteExpiresAt %v", touched.ExpiresAt, token.AbsoluteExpiresAt)
+	}
+}
+
+func TestManagerTouchAfterIdleDeadlineReturnsErrExpired(t *testing.T) {
+	ctx := context.Background()
+	start := time.Unix(0, 0)
+	m := newManagerAt(t, start, nil)
+
+	const secret = "session-secret"
+	_, payload, err := m.GenerateSession(ctx, secret, "alice", "checkin", 5*time.Minute, time.Minute)
+	if err != nil {
+		t.Fatalf("GenerateSession: %v", err)
+	}
+
+	// Let the idle timeout lapse without a touch.
+	m.now = func() time.Time { return start.Add(2 * time.Minute) }
+	if _, err := m.Touch(ctx, secret, payload); !errors.Is(err, ErrExpired) {
+		t.Fatalf("Touch after idle deadline: got %v, want ErrExpired", err)
+	}
+}
+
+func TestManagerVerifyLeavesSessionTokenReVerifiable(t *testing.T) {
+	ctx := context.Background()
+	start := time.Unix(0, 0)
+	m := newManagerAt(t, start, nil)
+
+	const secret = "session-secret"
+	_, payload, err := m.GenerateSession(ctx, secret, "alice", "checkin", 5*time.Minute, time.Minute)
+	if err != nil {
+		t.Fatalf("GenerateSession: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		token, err := m.Verify(ctx, secret, payload)
+		if err != nil {
+			t.Fatalf("Verify #%d: %v", i, err)
+		}
+		if token.Used {
+			t.Fatalf("Verify #%d: session token marked Used", i)
+		}
+	}
+}
+
+func TestManagerVerifyV2LeavesSessionTokenReVerifiable(t *testing.T) {
+	ctx := context.Background()
+	start := time.Unix(0, 0)
+	const secret = "session-secret"
+	keys := NewKeySet().AddKey("v1", NewSigner(secret))
+	m := newManagerAt(t, start, keys)
+
+	_, payload, err := m.GenerateSession(ctx, secret, "alice", "checkin", 5*time.Minute, time.Minute)
+	if err != nil {
+		t.Fatalf("GenerateSession: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		token, err := m.VerifyV2(ctx, payload)
+		if err != nil {
+			t.Fatalf("VerifyV2 #%d: %v", i, err)
+		}
+		if token.Used {
+			t.Fatalf("VerifyV2 #%d: session token marked Used", i)
+		}
+	}
+}
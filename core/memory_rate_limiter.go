@@ -1,47 +1,81 @@
 package core
 
 import (
+	"container/list"
 	"context"
 	"sync"
 	"time"
 )
 
+// MemoryRateLimiter is a sliding-window rate limiter for single-process
+// deployments, mirroring the semantics of RedisRateLimiter. Each user keeps a
+// list of recent request timestamps; entries older than the window are
+// trimmed off the front on every check, and idle users are swept
+// periodically so the map doesn't grow unbounded.
 type MemoryRateLimiter struct {
-	mu    sync.RWMutex
-	users map[string]*userLimit
-}
-
-type userLimit struct {
-	count     int
-	windowEnd time.Time
+	mu          sync.Mutex
+	timestamps  map[string]*list.List
+	lastSeen    map[string]time.Time
+	idleTimeout time.Duration
+	lastSweep   time.Time
 }
 
 func NewMemoryRateLimiter() *MemoryRateLimiter {
 	return &MemoryRateLimiter{
-		users: make(map[string]*userLimit),
+		timestamps:  make(map[string]*list.List),
+		lastSeen:    make(map[string]time.Time),
+		idleTimeout: 10 * time.Minute,
+		lastSweep:   time.Now(),
 	}
 }
 
-func (r *MemoryRateLimiter) CheckAndIncrement(ctx context.Context, userID string, limit int, window time.Duration) error {
+func (r *MemoryRateLimiter) CheckAndIncrement(ctx context.Context, userID string, limit int, window time.Duration) (time.Duration, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
 	now := time.Now()
-	ul, exists := r.users[userID]
+	r.gcIdle(now)
 
-	if !exists || now.After(ul.windowEnd) {
-		r.users[userID] = &userLimit{
-			count:     1,
-			windowEnd: now.Add(window),
+	times, ok := r.timestamps[userID]
+	if !ok {
+		times = list.New()
+		r.timestamps[userID] = times
+	}
+	r.lastSeen[userID] = now
+
+	cutoff := now.Add(-window)
+	for e := times.Front(); e != nil; {
+		next := e.Next()
+		if e.Value.(time.Time).Before(cutoff) {
+			times.Remove(e)
 		}
-		return nil
+		e = next
 	}
 
-	if ul.count >= limit {
-		return ErrRateLimitExceeded
+	if times.Len() >= limit {
+		oldest := times.Front().Value.(time.Time)
+		retryAfter := oldest.Add(window).Sub(now)
+		if retryAfter < 0 {
+			retryAfter = 0
+		}
+		return retryAfter, ErrRateLimitExceeded
 	}
 
-	ul.count++
-	return nil
+	times.PushBack(now)
+	return 0, nil
 }
 
+// gcIdle drops users whose most recent request is older than idleTimeout. It
+// runs at most once per idleTimeout so it doesn't add overhead to every check.
+func (r *MemoryRateLimiter) gcIdle(now time.Time) {
+	if now.Sub(r.lastSweep) < r.idleTimeout {
+		return
+	}
+	r.lastSweep = now
+	for userID, seen := range r.lastSeen {
+		if now.Sub(seen) >= r.idleTimeout {
+			delete(r.timestamps, userID)
+			delete(r.lastSeen, userID)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryRateLimiterAllowsUpToLimitThenBlocks(t *testing.T) {
+	ctx := context.Background()
+	rl := NewMemoryRateLimiter()
+	const limit = 3
+	window := 100 * time.Millisecond
+
+	for i := 0; i < limit; i++ {
+		if _, err := rl.CheckAndIncrement(ctx, "alice", limit, window); err != nil {
+			t.Fatalf("request %d: unexpected error: %v", i, err)
+		}
+	}
+
+	if _, err := rl.CheckAndIncrement(ctx, "alice", limit, window); err != ErrRateLimitExceeded {
+		t.Fatalf("expected ErrRateLimitExceeded once the limit is reached, got %v", err)
+	}
+
+	time.Sleep(window + 20*time.Millisecond)
+
+	if _, err := rl.CheckAndIncrement(ctx, "alice", limit, window); err != nil {
+		t.Fatalf("after the window elapses, request should succeed, got %v", err)
+	}
+}
+
+// TestMemoryRateLimiterNoDoubleBurstAtWindowEdge guards against the
+// fixed-window bug this limiter replaced: a fixed window resets its counter
+// at a boundary, so a burst just before the boundary and another just after
+// lets a user through at up to 2x the limit. A true sliding window always
+// looks back exactly `window` from now, so it must still see the first
+// burst's still-live requests mid-window and reject most of the second one.
+func TestMemoryRateLimiterNoDoubleBurstAtWindowEdge(t *testing.T) {
+	ctx := context.Background()
+	rl := NewMemoryRateLimiter()
+	const limit = 5
+	window := 200 * time.Millisecond
+
+	for i := 0; i < limit; i++ {
+		if _, err := rl.CheckAndIncrement(ctx, "bob", limit, window); err != nil {
+			t.Fatalf("initial burst request %d: unexpected error: %v", i, err)
+		}
+	}
+
+	time.Sleep(window / 2)
+
+	allowed := 0
+	for i := 0; i < limit; i++ {
+		if _, err := rl.CheckAndIncrement(ctx, "bob", limit, window); err == nil {
+			allowed++
+		}
+	}
+	if allowed >= limit {
+		t.Fatalf("sliding window let %d additional requests through mid-window; want fewer than %d", allowed, limit)
+	}
+}
+
+func TestMemoryRateLimiterTracksUsersIndependently(t *testing.T) {
+	ctx := context.Background()
+	rl := NewMemoryRateLimiter()
+	const limit = 1
+	window := time.Minute
+
+	if _, err := rl.CheckAndIncrement(ctx, "alice", limit, window); err != nil {
+		t.Fatalf("alice: unexpected error: %v", err)
+	}
+	if _, err := rl.CheckAndIncrement(ctx, "bob", limit, window); err != nil {
+		t.Fatalf("bob should have its own limit independent of alice, got %v", err)
+	}
+	if _, err := rl.CheckAndIncrement(ctx, "alice", limit, window); err != ErrRateLimitExceeded {
+		t.Fatalf("alice should be rate limited on her second request, got %v", err)
+	}
+}
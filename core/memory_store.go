@@ -2,6 +2,7 @@ package core
 
 import (
 	"context"
+	"fmt"
 	"sync"
 	"time"
 
@@ -48,3 +49,69 @@ func (s *MemoryStore) MarkUsed(_ context.Context, id uuid.UUID) error {
 	return nil
 }
 
+func (s *MemoryStore) Delete(_ context.Context, id uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data, id)
+	return nil
+}
+
+func (s *MemoryStore) DeleteByUser(_ context.Context, userID string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	count := 0
+	for id, t := range s.data {
+		if t.UserID == userID {
+			delete(s.data, id)
+			count++
+		}
+	}
+	return count, nil
+}
+
+// Touch extends a session token's idle deadline by its own IdleTimeout,
+// capped at AbsoluteExpiresAt. If the token's current ExpiresAt has already
+// passed, the idle timeout has already elapsed and the token is dead for
+// good: Touch reports ErrExpired rather than reviving it.
+func (s *MemoryStore) Touch(_ context.Context, id uuid.UUID, now time.Time) (*Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.data[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	if t.IdleTimeout <= 0 {
+		return nil, fmt.Errorf("token is not a session token")
+	}
+	if t.ExpiresAt.Before(now) {
+		return nil, ErrExpired
+	}
+
+	newExpiry := now.Add(t.IdleTimeout)
+	if !t.AbsoluteExpiresAt.IsZero() && newExpiry.After(t.AbsoluteExpiresAt) {
+		newExpiry = t.AbsoluteExpiresAt
+	}
+	if !newExpiry.After(now) {
+		return nil, ErrExpired
+	}
+
+	t.ExpiresAt = newExpiry
+	s.data[id] = t
+	return &t, nil
+}
+
+// PurgeLapsed deletes tokens whose ExpiresAt is before the given time. The
+// in-memory store doesn't need this to stay bounded (entries don't outlive
+// the process), but it's implemented for parity with the other backends.
+func (s *MemoryStore) PurgeLapsed(_ context.Context, before time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	count := 0
+	for id, t := range s.data {
+		if t.ExpiresAt.Before(before) {
+			delete(s.data, id)
+			count++
+		}
+	}
+	return count, nil
+}
@@ -3,31 +3,115 @@ package core
 import (
 	"encoding/base64"
 	"encoding/json"
+	"strings"
 )
 
+// payload is the v1 wire format: base64url JSON of {id, sig}, signed with a
+// single shared HMAC secret. Kept for backward compatibility with tokens
+// issued before the v2 format existed.
 type payload struct {
 	ID  string `json:"id"`
 	Sig string `json:"sig"`
 }
 
+type payloadHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type payloadBody struct {
+	ID string `json:"id"`
+}
+
+// decodedPayload is the shape Manager.Verify works with regardless of which
+// wire version produced it.
+type decodedPayload struct {
+	Version string // "v1" or "v2"
+	ID      string
+	Kid     string // only set for v2
+	Sig     string
+}
+
+// EncodePayload builds the v1 wire format: base64url JSON of {id, sig}.
 func EncodePayload(id, signature string) (string, error) {
-	data := payload{ID: id, Sig: signature}
-	raw, err := json.Marshal(data)
+	raw, err := json.Marshal(payload{ID: id, Sig: signature})
 	if err != nil {
 		return "", err
 	}
 	return base64.RawURLEncoding.EncodeToString(raw), nil
 }
 
-func DecodePayload(encoded string) (payload, error) {
-	var data payload
+// EncodePayloadV2 builds the versioned wire format
+// "v2.<b64url(header)>.<b64url(body)>.<b64url(sig)>", where header carries
+// the signing alg and kid so Manager.VerifyV2 can pick the right key during
+// rotation. signature is the caller-supplied signature over the raw token id
+// bytes, produced by the Signer identified by kid.
+func EncodePayloadV2(kid, alg, id, signature string) (string, error) {
+	header, err := json.Marshal(payloadHeader{Alg: alg, Kid: kid})
+	if err != nil {
+		return "", err
+	}
+	body, err := json.Marshal(payloadBody{ID: id})
+	if err != nil {
+		return "", err
+	}
+	return strings.Join([]string{
+		"v2",
+		base64.RawURLEncoding.EncodeToString(header),
+		base64.RawURLEncoding.EncodeToString(body),
+		base64.RawURLEncoding.EncodeToString([]byte(signature)),
+	}, "."), nil
+}
+
+// DecodePayload parses either wire format. v1 payloads (no "v2." prefix) are
+// returned with Version "v1" and no Kid.
+func DecodePayload(encoded string) (decodedPayload, error) {
+	if strings.HasPrefix(encoded, "v2.") {
+		return decodePayloadV2(encoded)
+	}
+	return decodePayloadV1(encoded)
+}
+
+func decodePayloadV1(encoded string) (decodedPayload, error) {
 	raw, err := base64.RawURLEncoding.DecodeString(encoded)
 	if err != nil {
-		return data, ErrBadPayload
+		return decodedPayload{}, ErrBadPayload
 	}
+	var data payload
 	if err := json.Unmarshal(raw, &data); err != nil {
-		return data, ErrBadPayload
+		return decodedPayload{}, ErrBadPayload
 	}
-	return data, nil
+	return decodedPayload{Version: "v1", ID: data.ID, Sig: data.Sig}, nil
 }
 
+func decodePayloadV2(encoded string) (decodedPayload, error) {
+	parts := strings.Split(encoded, ".")
+	if len(parts) != 4 {
+		return decodedPayload{}, ErrBadPayload
+	}
+
+	headerRaw, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return decodedPayload{}, ErrBadPayload
+	}
+	var header payloadHeader
+	if err := json.Unmarshal(headerRaw, &header); err != nil {
+		return decodedPayload{}, ErrBadPayload
+	}
+
+	bodyRaw, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return decodedPayload{}, ErrBadPayload
+	}
+	var body payloadBody
+	if err := json.Unmarshal(bodyRaw, &body); err != nil {
+		return decodedPayload{}, ErrBadPayload
+	}
+
+	sigRaw, err := base64.RawURLEncoding.DecodeString(parts[3])
+	if err != nil {
+		return decodedPayload{}, ErrBadPayload
+	}
+
+	return decodedPayload{Version: "v2", ID: body.ID, Kid: header.Kid, Sig: string(sigRaw)}, nil
+}
@@ -0,0 +1,44 @@
+package core
+
+import "testing"
+
+func TestEncodeDecodePayloadV1(t *testing.T) {
+	encoded, err := EncodePayload("token-id", "sig")
+	if err != nil {
+		t.Fatalf("EncodePayload: %v", err)
+	}
+	data, err := DecodePayload(encoded)
+	if err != nil {
+		t.Fatalf("DecodePayload: %v", err)
+	}
+	if data.Version != "v1" || data.ID != "token-id" || data.Sig != "sig" || data.Kid != "" {
+		t.Fatalf("unexpected decoded payload: %+v", data)
+	}
+}
+
+func TestEncodeDecodePayloadV2(t *testing.T) {
+	encoded, err := EncodePayloadV2("key-1", "HS256", "token-id", "sig")
+	if err != nil {
+		t.Fatalf("EncodePayloadV2: %v", err)
+	}
+	data, err := DecodePayload(encoded)
+	if err != nil {
+		t.Fatalf("DecodePayload: %v", err)
+	}
+	if data.Version != "v2" || data.ID != "token-id" || data.Sig != "sig" || data.Kid != "key-1" {
+		t.Fatalf("unexpected decoded payload: %+v", data)
+	}
+}
+
+func TestDecodePayloadRejectsMalformedInput(t *testing.T) {
+	cases := []string{
+		"not valid base64!!!",
+		"v2.onlyonepart",
+		"v2.aGVsbG8.aGVsbG8.aGVsbG8", // "hello" isn't valid JSON for header/body
+	}
+	for _, encoded := range cases {
+		if _, err := DecodePayload(encoded); err != ErrBadPayload {
+			t.Errorf("DecodePayload(%q): expected ErrBadPayload, got %v", encoded, err)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package core
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func init() {
+	RegisterStore("postgres", func(dsn string) (Store, error) {
+		db, err := sql.Open("postgres", dsn)
+		if err != nil {
+			return nil, err
+		}
+		if err := db.Ping(); err != nil {
+			return nil, err
+		}
+		return NewPostgresStore(db), nil
+	})
+}
+
+// PostgresStore persists tokens in a qr_tokens table, giving durable storage
+// beyond the in-memory and Redis backends. Callers are responsible for
+// blank-importing a database/sql driver registered as "postgres" (e.g.
+// github.com/lib/pq) and for creating the schema:
+//
+//	CREATE TABLE qr_tokens (
+//	    id UUID PRIMARY KEY,
+//	    user_id TEXT NOT NULL,
+//	    action TEXT NOT NULL,
+//	    expires_at TIMESTAMPTZ NOT NULL,
+//	    used BOOLEAN NOT NULL DEFAULT false,
+//	    absolute_expires_at TIMESTAMPTZ,
+//	    idle_timeout_ms BIGINT,
+//	    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+//	);
+//	CREATE INDEX qr_tokens_expires_at_idx ON qr_tokens (expires_at);
+//
+// absolute_expires_at and idle_timeout_ms are only set on session tokens (see
+// Manager.GenerateSession); they're NULL for tokens created by the default
+// single-use Generate path.
+//
+// Unlike Redis, rows don't expire on their own; pair PostgresStore with a
+// periodic Manager.Purge(PurgeOptions{Scope: PurgeScopeLapsed}) (which
+// PostgresStore supports via LapsedPurger) to evict them.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore wraps an already-opened *sql.DB. The caller owns the
+// connection pool's lifecycle.
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Save(ctx context.Context, t Token, _ time.Duration) error {
+	var absoluteExpiresAt sql.NullTime
+	var idleTimeoutMs sql.NullInt64
+	if t.IdleTimeout > 0 {
+		absoluteExpiresAt = sql.NullTime{Time: t.AbsoluteExpiresAt, Valid: true}
+		idleTimeoutMs = sql.NullInt64{Int64: t.IdleTimeout.Milliseconds(), Valid: true}
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO qr_tokens (id, user_id, action, expires_at, used, absolute_expires_at, idle_timeout_ms)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, t.ID, t.UserID, t.Action, t.ExpiresAt, t.Used, absoluteExpiresAt, idleTimeoutMs)
+	return err
+}
+
+func (s *PostgresStore) Get(ctx context.Context, id uuid.UUID) (*Token, error) {
+	var t Token
+	var absoluteExpiresAt sql.NullTime
+	var idleTimeoutMs sql.NullInt64
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, user_id, action, expires_at, used, absolute_expires_at, idle_timeout_ms
+		FROM qr_tokens
+		WHERE id = $1
+	`, id).Scan(&t.ID, &t.UserID, &t.Action, &t.ExpiresAt, &t.Used, &absoluteExpiresAt, &idleTimeoutMs)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if idleTimeoutMs.Valid {
+		t.AbsoluteExpiresAt = absoluteExpiresAt.Time
+		t.IdleTimeout = time.Duration(idleTimeoutMs.Int64) * time.Millisecond
+	}
+	return &t, nil
+}
+
+// MarkUsed flips the used flag atomically and distinguishes "already used"
+// from "not found", the same semantics RedisStore.MarkUsed provides.
+func (s *PostgresStore) MarkUsed(ctx context.Context, id uuid.UUID) error {
+	var returnedID uuid.UUID
+	err := s.db.QueryRowContext(ctx, `
+		UPDATE qr_tokens SET used = true
+		WHERE id = $1 AND used = false
+		RETURNING id
+	`, id).Scan(&returnedID)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	var used bool
+	scanErr := s.db.QueryRowContext(ctx, `SELECT used FROM qr_tokens WHERE id = $1`, id).Scan(&used)
+	if errors.Is(scanErr, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if scanErr != nil {
+		return scanErr
+	}
+	return ErrUsed
+}
+
+func (s *PostgresStore) Delete(ctx context.Context, id uuid.UUID) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM qr_tokens WHERE id = $1`, id)
+	return err
+}
+
+func (s *PostgresStore) DeleteByUser(ctx context.Context, userID string) (int, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM qr_tokens WHERE user_id = $1`, userID)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// PurgeLapsed deletes rows whose expires_at is before the given time. It
+// implements LapsedPurger, since Postgres rows (unlike Redis keys) need an
+// explicit sweep instead of expiring on their own.
+func (s *PostgresStore) PurgeLapsed(ctx context.Context, before time.Time) (int, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM qr_tokens WHERE expires_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
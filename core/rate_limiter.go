@@ -5,7 +5,10 @@ import (
 	"time"
 )
 
+// RateLimiter enforces a sliding request-count limit per user. On success it
+// returns a zero retryAfter; when the limit is exceeded it returns
+// ErrRateLimitExceeded along with the duration the caller should wait before
+// the window has room again.
 type RateLimiter interface {
-	CheckAndIncrement(ctx context.Context, userID string, limit int, window time.Duration) error
+	CheckAndIncrement(ctx context.Context, userID string, limit int, window time.Duration) (retryAfter time.Duration, err error)
 }
-
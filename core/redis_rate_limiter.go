@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 )
 
@@ -27,32 +28,46 @@ func (r *RedisRateLimiter) key(userID string) string {
 	return fmt.Sprintf("%s%s", r.keyPrefix, userID)
 }
 
-func (r *RedisRateLimiter) CheckAndIncrement(ctx context.Context, userID string, limit int, window time.Duration) error {
-	key := r.key(userID)
-
-	script := redis.NewScript(`
-		local current = redis.call("GET", KEYS[1])
-		if current == false then
-			redis.call("SET", KEYS[1], 1, "EX", ARGV[2])
-			return 1
-		end
-		local count = tonumber(current)
-		if count >= tonumber(ARGV[1]) then
-			return 0
-		end
-		redis.call("INCR", KEYS[1])
-		return 1
-	`)
-
-	result, err := script.Run(ctx, r.client, []string{key}, limit, int(window.Seconds())).Int()
+// slidingWindowScript implements a true sliding window over a per-user sorted
+// set: entries older than the window are trimmed, and the request is admitted
+// if what's left is under the limit. It returns 0 on admission, or the score
+// (unix ms) of the oldest surviving entry so the caller can derive a
+// Retry-After.
+var slidingWindowScript = redis.NewScript(`
+local key = KEYS[1]
+local now = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local limit = tonumber(ARGV[3])
+local member = ARGV[4]
+
+redis.call("ZREMRANGEBYSCORE", key, 0, now - window)
+local count = redis.call("ZCARD", key)
+if count < limit then
+	redis.call("ZADD", key, now, member)
+	redis.call("PEXPIRE", key, window)
+	return 0
+end
+
+local oldest = redis.call("ZRANGE", key, 0, 0, "WITHSCORES")
+return tonumber(oldest[2])
+`)
+
+func (r *RedisRateLimiter) CheckAndIncrement(ctx context.Context, userID string, limit int, window time.Duration) (time.Duration, error) {
+	now := time.Now().UnixMilli()
+	windowMs := window.Milliseconds()
+	member := uuid.New().String()
+
+	oldest, err := slidingWindowScript.Run(ctx, r.client, []string{r.key(userID)}, now, windowMs, limit, member).Int64()
 	if err != nil {
-		return err
+		return 0, err
 	}
-
-	if result == 0 {
-		return ErrRateLimitExceeded
+	if oldest == 0 {
+		return 0, nil
 	}
 
-	return nil
+	retryAfter := time.Duration(oldest+windowMs-now) * time.Millisecond
+	if retryAfter < 0 {
+		retryAfter = 0
+	}
+	return retryAfter, ErrRateLimitExceeded
 }
-
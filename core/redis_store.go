@@ -29,12 +29,76 @@ func (s *RedisStore) key(id uuid.UUID) string {
 	return fmt.Sprintf("%s%s", s.keyPrefix, id.String())
 }
 
+func (s *RedisStore) userSetKey(userID string) string {
+	return fmt.Sprintf("%suser:%s", s.keyPrefix, userID)
+}
+
+// redisRecord is the on-the-wire shape of a stored token. Alongside the
+// Token fields it mirrors ExpiresAt/AbsoluteExpiresAt/IdleTimeout as unix-ms
+// integers so the Touch Lua script can do the idle-timeout cap-and-extend
+// arithmetic without needing to parse or format RFC3339 timestamps itself.
+type redisRecord struct {
+	Token
+	ExpiresAtMs         int64 `json:"expires_at_ms,omitempty"`
+	AbsoluteExpiresAtMs int64 `json:"absolute_expires_at_ms,omitempty"`
+	IdleTimeoutMs       int64 `json:"idle_timeout_ms,omitempty"`
+}
+
+func newRedisRecord(t Token) redisRecord {
+	r := redisRecord{Token: t, ExpiresAtMs: t.ExpiresAt.UnixMilli()}
+	if t.IdleTimeout > 0 {
+		r.AbsoluteExpiresAtMs = t.AbsoluteExpiresAt.UnixMilli()
+		r.IdleTimeoutMs = t.IdleTimeout.Milliseconds()
+	}
+	return r
+}
+
+// MarshalJSON encodes the Token fields together with the *_ms mirrors. The
+// embedded Token's own MarshalJSON would otherwise be promoted to
+// redisRecord and drop the *_ms fields from the stored record.
+func (r redisRecord) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		tokenAlias
+		ExpiresAtMs         int64 `json:"expires_at_ms,omitempty"`
+		AbsoluteExpiresAtMs int64 `json:"absolute_expires_at_ms,omitempty"`
+		IdleTimeoutMs       int64 `json:"idle_timeout_ms,omitempty"`
+	}{tokenAlias(r.Token), r.ExpiresAtMs, r.AbsoluteExpiresAtMs, r.IdleTimeoutMs})
+}
+
+func (r redisRecord) toToken() Token {
+	t := r.Token
+	t.ExpiresAt = time.UnixMilli(r.ExpiresAtMs)
+	return t
+}
+
+// userSetExtendScript adds id to the per-user set and extends the set's own
+// TTL to cover it, rather than letting the set outlive every token added to
+// it. Member ids aren't individually removed as their tokens expire (most
+// tokens are never explicitly purged, so tracking that would mean an extra
+// write per natural expiry); instead the whole set's TTL tracks the
+// longest-lived member, so an inactive user's set still expires on its own.
+var userSetExtendScript = redis.NewScript(`
+redis.call("SADD", KEYS[1], ARGV[1])
+local ttl = redis.call("PTTL", KEYS[1])
+local newTTL = tonumber(ARGV[2])
+if ttl < 0 or newTTL > ttl then
+	redis.call("PEXPIRE", KEYS[1], newTTL)
+end
+return 1
+`)
+
+// Save writes the token and adds its id to a per-user set ({keyPrefix}user:{uid})
+// so DeleteByUser can revoke every outstanding token for a user without a
+// full scan.
 func (s *RedisStore) Save(ctx context.Context, t Token, ttl time.Duration) error {
-	raw, err := json.Marshal(t)
+	raw, err := json.Marshal(newRedisRecord(t))
 	if err != nil {
 		return err
 	}
-	return s.client.Set(ctx, s.key(t.ID), raw, ttl).Err()
+	if err := s.client.Set(ctx, s.key(t.ID), raw, ttl).Err(); err != nil {
+		return err
+	}
+	return userSetExtendScript.Run(ctx, s.client, []string{s.userSetKey(t.UserID)}, t.ID.String(), ttl.Milliseconds()).Err()
 }
 
 func (s *RedisStore) Get(ctx context.Context, id uuid.UUID) (*Token, error) {
@@ -45,10 +109,11 @@ func (s *RedisStore) Get(ctx context.Context, id uuid.UUID) (*Token, error) {
 	if err != nil {
 		return nil, err
 	}
-	var t Token
-	if err := json.Unmarshal([]byte(val), &t); err != nil {
+	var rec redisRecord
+	if err := json.Unmarshal([]byte(val), &rec); err != nil {
 		return nil, err
 	}
+	t := rec.toToken()
 	return &t, nil
 }
 
@@ -82,3 +147,96 @@ return 1
 	}
 }
 
+// touchScript extends a session token's idle deadline, capped at its
+// absolute expiry, reading and writing expires_at_ms atomically so a
+// concurrent Touch/Verify can't observe a half-updated record. If the
+// stored expires_at_ms is already in the past, the idle timeout has already
+// elapsed and the token is dead for good; the script reports that rather
+// than reviving it.
+var touchScript = redis.NewScript(`
+local val = redis.call("GET", KEYS[1])
+if not val then return 0 end
+local obj = cjson.decode(val)
+if not obj.idle_timeout_ms or obj.idle_timeout_ms == 0 then return -2 end
+
+local now = tonumber(ARGV[1])
+if obj.expires_at_ms and obj.expires_at_ms < now then return -1 end
+
+local newExpiry = now + obj.idle_timeout_ms
+if obj.absolute_expires_at_ms and newExpiry > obj.absolute_expires_at_ms then
+	newExpiry = obj.absolute_expires_at_ms
+end
+local ttl = newExpiry - now
+if ttl <= 0 then return -1 end
+
+obj.expires_at_ms = newExpiry
+local raw = cjson.encode(obj)
+redis.call("SET", KEYS[1], raw, "PX", ttl)
+return raw
+`)
+
+// Touch extends id's idle deadline (Token.ExpiresAt) by its stored
+// IdleTimeout, capped at AbsoluteExpiresAt, via a Lua script that reads and
+// rewrites the record with SET ... PX set to the new, capped TTL.
+func (s *RedisStore) Touch(ctx context.Context, id uuid.UUID, now time.Time) (*Token, error) {
+	res, err := touchScript.Run(ctx, s.client, []string{s.key(id)}, now.UnixMilli()).Result()
+	if err != nil {
+		return nil, err
+	}
+	switch v := res.(type) {
+	case int64:
+		switch v {
+		case 0:
+			return nil, ErrNotFound
+		case -1:
+			return nil, ErrExpired
+		case -2:
+			return nil, fmt.Errorf("token is not a session token")
+		default:
+			return nil, fmt.Errorf("redis: unexpected touch result %d", v)
+		}
+	case string:
+		var rec redisRecord
+		if err := json.Unmarshal([]byte(v), &rec); err != nil {
+			return nil, err
+		}
+		t := rec.toToken()
+		return &t, nil
+	default:
+		return nil, fmt.Errorf("redis: unexpected touch result type %T", res)
+	}
+}
+
+func (s *RedisStore) Delete(ctx context.Context, id uuid.UUID) error {
+	return s.client.Del(ctx, s.key(id)).Err()
+}
+
+// DeleteByUser revokes every outstanding token for userID via the per-user
+// set maintained in Save, pipelining the deletes instead of scanning all
+// keys under the prefix.
+func (s *RedisStore) DeleteByUser(ctx context.Context, userID string) (int, error) {
+	setKey := s.userSetKey(userID)
+	ids, err := s.client.SMembers(ctx, setKey).Result()
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	pipe := s.client.Pipeline()
+	cmds := make([]*redis.IntCmd, len(ids))
+	for i, id := range ids {
+		cmds[i] = pipe.Del(ctx, s.keyPrefix+id)
+	}
+	pipe.Del(ctx, setKey)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	for _, cmd := range cmds {
+		deleted += int(cmd.Val())
+	}
+	return deleted, nil
+}
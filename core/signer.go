@@ -1,28 +1,81 @@
 package core
 
 import (
+	"crypto/ed25519"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/base64"
 )
 
-type Signer struct {
+// Signer produces and verifies signatures over raw bytes. Algorithm names the
+// scheme so v2 payloads can record which algorithm produced them (see
+// EncodePayloadV2); Manager.VerifyV2 picks the right key during rotation by
+// the payload's kid.
+type Signer interface {
+	Algorithm() string
+	Sign(data []byte) string
+	Verify(data []byte, signature string) bool
+}
+
+// HMACSigner is the original symmetric signer: a single shared secret, no
+// key id. It's kept for the v1 payload format and for deployments that don't
+// need key rotation.
+type HMACSigner struct {
 	secret []byte
 }
 
-func NewSigner(secret string) *Signer {
-	return &Signer{secret: []byte(secret)}
+// NewSigner builds an HMACSigner from secret.
+func NewSigner(secret string) *HMACSigner {
+	return &HMACSigner{secret: []byte(secret)}
 }
 
-func (s *Signer) Sign(idBytes []byte) string {
+func (s *HMACSigner) Algorithm() string { return "HS256" }
+
+func (s *HMACSigner) Sign(data []byte) string {
 	mac := hmac.New(sha256.New, s.secret)
-	mac.Write(idBytes)
+	mac.Write(data)
 	sum := mac.Sum(nil)
 	return base64.RawURLEncoding.EncodeToString(sum)
 }
 
-func (s *Signer) Verify(idBytes []byte, signature string) bool {
-	expected := s.Sign(idBytes)
+func (s *HMACSigner) Verify(data []byte, signature string) bool {
+	expected := s.Sign(data)
 	return hmac.Equal([]byte(expected), []byte(signature))
 }
 
+// Ed25519Signer signs with an Ed25519 key pair, so verification can be done
+// offline from the public key alone without sharing the signing secret.
+type Ed25519Signer struct {
+	public  ed25519.PublicKey
+	private ed25519.PrivateKey
+}
+
+// NewEd25519Signer builds a signer able to both sign and verify. Use
+// NewEd25519Verifier for a verify-only signer built from just the public key.
+func NewEd25519Signer(private ed25519.PrivateKey) *Ed25519Signer {
+	return &Ed25519Signer{private: private, public: private.Public().(ed25519.PublicKey)}
+}
+
+// NewEd25519Verifier builds a verify-only signer from a public key. Sign
+// panics if called on a signer built this way.
+func NewEd25519Verifier(public ed25519.PublicKey) *Ed25519Signer {
+	return &Ed25519Signer{public: public}
+}
+
+func (s *Ed25519Signer) Algorithm() string { return "Ed25519" }
+
+func (s *Ed25519Signer) Sign(data []byte) string {
+	if s.private == nil {
+		panic("core: Ed25519Signer has no private key to sign with")
+	}
+	sig := ed25519.Sign(s.private, data)
+	return base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func (s *Ed25519Signer) Verify(data []byte, signature string) bool {
+	sig, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	return ed25519.Verify(s.public, data, sig)
+}
@@ -0,0 +1,59 @@
+package core
+
+import (
+	"crypto/ed25519"
+	"testing"
+)
+
+func TestHMACSignerSignVerify(t *testing.T) {
+	signer := NewSigner("secret")
+	data := []byte("token-id-bytes")
+
+	sig := signer.Sign(data)
+	if !signer.Verify(data, sig) {
+		t.Fatal("Verify rejected a signature Sign just produced")
+	}
+	if signer.Verify([]byte("tampered"), sig) {
+		t.Fatal("Verify accepted a signature for different data")
+	}
+	if NewSigner("other-secret").Verify(data, sig) {
+		t.Fatal("Verify accepted a signature produced with a different secret")
+	}
+}
+
+func TestEd25519SignerSignVerify(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	signer := NewEd25519Signer(priv)
+	data := []byte("token-id-bytes")
+
+	sig := signer.Sign(data)
+	if !signer.Verify(data, sig) {
+		t.Fatal("Verify rejected a signature Sign just produced")
+	}
+
+	verifier := NewEd25519Verifier(pub)
+	if !verifier.Verify(data, sig) {
+		t.Fatal("verify-only signer rejected a signature valid under the matching public key")
+	}
+	if verifier.Verify([]byte("tampered"), sig) {
+		t.Fatal("verify-only signer accepted a signature for different data")
+	}
+}
+
+func TestEd25519VerifierPanicsOnSign(t *testing.T) {
+	_, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	verifier := NewEd25519Verifier(priv.Public().(ed25519.PublicKey))
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected Sign to panic on a verify-only signer")
+		}
+	}()
+	verifier.Sign([]byte("data"))
+}
@@ -11,5 +11,22 @@ type Store interface {
 	Save(ctx context.Context, t Token, ttl time.Duration) error
 	Get(ctx context.Context, id uuid.UUID) (*Token, error)
 	MarkUsed(ctx context.Context, id uuid.UUID) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	DeleteByUser(ctx context.Context, userID string) (int, error)
 }
 
+// LapsedPurger is implemented by Store backends that don't expire entries on
+// their own (unlike Redis keys, which carry a TTL) and so need an explicit
+// sweep to evict tokens whose ExpiresAt has passed.
+type LapsedPurger interface {
+	PurgeLapsed(ctx context.Context, before time.Time) (int, error)
+}
+
+// Toucher is implemented by Store backends that support extending a session
+// token's idle deadline (Token.ExpiresAt), capped at its
+// Token.AbsoluteExpiresAt, without marking it used. now is the Manager's
+// clock (Config.Now, defaulting to time.Now) rather than the backend's own,
+// so Touch's expiry checks stay consistent with Verify's.
+type Toucher interface {
+	Touch(ctx context.Context, id uuid.UUID, now time.Time) (*Token, error)
+}
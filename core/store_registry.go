@@ -0,0 +1,34 @@
+package core
+
+import "fmt"
+
+// StoreFactory constructs a Store from a driver-specific DSN. Backends
+// register a factory under a name so callers can select a Store through
+// configuration (ManagerOptions.StoreDriver/StoreDSN) instead of importing
+// and wiring up a concrete type, mirroring the database/sql driver registry.
+type StoreFactory func(dsn string) (Store, error)
+
+var storeFactories = make(map[string]StoreFactory)
+
+// RegisterStore makes a Store backend available under name. Backend packages
+// call this from an init function. It panics on a duplicate registration,
+// matching the behavior of sql.Register.
+func RegisterStore(name string, factory StoreFactory) {
+	if factory == nil {
+		panic("core: RegisterStore factory is nil")
+	}
+	if _, dup := storeFactories[name]; dup {
+		panic("core: RegisterStore called twice for driver " + name)
+	}
+	storeFactories[name] = factory
+}
+
+// NewStore builds the Store backend registered under driver using dsn. It
+// returns an error if no backend has been registered under that name.
+func NewStore(driver, dsn string) (Store, error) {
+	factory, ok := storeFactories[driver]
+	if !ok {
+		return nil, fmt.Errorf("core: unknown store driver %q", driver)
+	}
+	return factory(dsn)
+}
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"encoding/json"
 	"errors"
 	"time"
 
@@ -13,14 +14,50 @@ type Token struct {
 	Action    string    `json:"action"`
 	ExpiresAt time.Time `json:"expires_at"`
 	Used      bool      `json:"used"`
+
+	// AbsoluteExpiresAt and IdleTimeout are set on tokens created by
+	// Manager.GenerateSession: ExpiresAt is the current idle deadline,
+	// extended by Manager.Touch but never past AbsoluteExpiresAt. Both are
+	// zero on tokens created by the default single-use Generate path.
+	AbsoluteExpiresAt time.Time     `json:"absolute_expires_at,omitempty"`
+	IdleTimeout       time.Duration `json:"idle_timeout,omitempty"`
+}
+
+// tokenAlias lets MarshalJSON reuse the struct tags above without recursing
+// into itself.
+type tokenAlias Token
+
+// MarshalJSON omits absolute_expires_at for non-session tokens. The
+// omitempty tag alone doesn't do this: time.Time is a struct, and
+// encoding/json's omitempty only looks at Go's zero values for basic kinds
+// (false, 0, "", nil), so a zero time.Time would otherwise always serialize
+// as "0001-01-01T00:00:00Z".
+func (t Token) MarshalJSON() ([]byte, error) {
+	wire := struct {
+		tokenAlias
+		AbsoluteExpiresAt *time.Time `json:"absolute_expires_at,omitempty"`
+	}{tokenAlias: tokenAlias(t)}
+	if !t.AbsoluteExpiresAt.IsZero() {
+		wire.AbsoluteExpiresAt = &t.AbsoluteExpiresAt
+	}
+	return json.Marshal(wire)
 }
 
 var (
-	ErrNotFound         = errors.New("token not found")
-	ErrExpired          = errors.New("token expired")
-	ErrUsed             = errors.New("token already used")
-	ErrBadSignature     = errors.New("signature mismatch")
-	ErrBadPayload       = errors.New("invalid payload")
+	ErrNotFound          = errors.New("token not found")
+	ErrExpired           = errors.New("token expired")
+	ErrUsed              = errors.New("token already used")
+	ErrBadSignature      = errors.New("signature mismatch")
+	ErrBadPayload        = errors.New("invalid payload")
 	ErrRateLimitExceeded = errors.New("rate limit exceeded")
 )
 
+// RateLimitError wraps ErrRateLimitExceeded with how long the caller should
+// wait before retrying, so HTTP handlers can set a Retry-After header.
+type RateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string { return ErrRateLimitExceeded.Error() }
+
+func (e *RateLimitError) Unwrap() error { return ErrRateLimitExceeded }